@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// Dialer abstracts how ScanPort opens a TCP connection, so scans can be
+// routed through a SOCKS5 proxy (tor, an ssh tunnel, a bastion) instead of
+// always dialing the target directly.
+type Dialer interface {
+	Dial(network, addr string) (net.Conn, error)
+}
+
+// timeoutDialer is the default Dialer: a direct net.DialTimeout.
+type timeoutDialer struct {
+	timeout time.Duration
+}
+
+func (d *timeoutDialer) Dial(network, addr string) (net.Conn, error) {
+	return net.DialTimeout(network, addr, d.timeout)
+}
+
+// socks5Dialer routes connections through a SOCKS5 proxy. proxy.Dialer has
+// no notion of a per-attempt timeout, so one is enforced here.
+type socks5Dialer struct {
+	inner   proxy.Dialer
+	timeout time.Duration
+}
+
+func (d *socks5Dialer) Dial(network, addr string) (net.Conn, error) {
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+
+	ch := make(chan result, 1)
+	go func() {
+		conn, err := d.inner.Dial(network, addr)
+		ch <- result{conn, err}
+	}()
+
+	select {
+	case res := <-ch:
+		return res.conn, res.err
+	case <-time.After(d.timeout):
+		// d.inner.Dial has no cancellation, so the goroutine above is
+		// still blocked on it. Drain its result in the background and
+		// close the connection if it eventually succeeds, rather than
+		// leaking the socket.
+		go func() {
+			if res := <-ch; res.conn != nil {
+				res.conn.Close()
+			}
+		}()
+		return nil, fmt.Errorf("dial %s via socks5: timeout", addr)
+	}
+}
+
+// NewDialer builds the Dialer to use for a scan. If socks5Addr is empty it
+// returns a plain timeout dialer; otherwise every connection is routed
+// through that SOCKS5 proxy, optionally authenticating with user/pass.
+func NewDialer(timeout time.Duration, socks5Addr, user, pass string) (Dialer, error) {
+	if socks5Addr == "" {
+		return &timeoutDialer{timeout: timeout}, nil
+	}
+
+	var auth *proxy.Auth
+	if user != "" || pass != "" {
+		auth = &proxy.Auth{User: user, Password: pass}
+	}
+
+	inner, err := proxy.SOCKS5("tcp", socks5Addr, auth, proxy.Direct)
+	if err != nil {
+		return nil, fmt.Errorf("configuring socks5 proxy: %w", err)
+	}
+
+	return &socks5Dialer{inner: inner, timeout: timeout}, nil
+}
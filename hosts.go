@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// expandHost turns a single -host/-hosts-file token into one or more
+// addresses to scan: a bare host/IP passes through unchanged, while a CIDR
+// block (e.g. "192.168.1.0/24") is expanded to every host address in it.
+func expandHost(token string) ([]string, error) {
+	if !strings.Contains(token, "/") {
+		return []string{token}, nil
+	}
+
+	ip, ipnet, err := net.ParseCIDR(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR %q: %w", token, err)
+	}
+
+	var hosts []string
+	for cur := ip.Mask(ipnet.Mask); ipnet.Contains(cur); incIP(cur) {
+		hosts = append(hosts, cur.String())
+	}
+
+	// Drop the network and broadcast addresses when the block is large
+	// enough to have them, matching how most scanners treat a /24 or wider.
+	if len(hosts) > 2 {
+		hosts = hosts[1 : len(hosts)-1]
+	}
+
+	return hosts, nil
+}
+
+// incIP increments an IP address in place, treating it as a big-endian
+// byte counter so it carries over correctly across octet boundaries.
+func incIP(ip net.IP) {
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]++
+		if ip[i] != 0 {
+			break
+		}
+	}
+}
+
+// loadHostsFile reads one host or CIDR block per line from path, skipping
+// blank lines and "#" comments, and expands any CIDR entries.
+func loadHostsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var hosts []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		expanded, err := expandHost(line)
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, expanded...)
+	}
+
+	return hosts, scanner.Err()
+}
+
+// resolveHosts builds the full target list from the -host flag and an
+// optional -hosts-file, expanding any CIDR blocks along the way.
+func resolveHosts(host, hostsFile string) ([]string, error) {
+	var hosts []string
+
+	if hostsFile != "" {
+		fileHosts, err := loadHostsFile(hostsFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading hosts file: %w", err)
+		}
+		hosts = append(hosts, fileHosts...)
+	}
+
+	if host != "" {
+		expanded, err := expandHost(host)
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, expanded...)
+	}
+
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no hosts specified (use -host or -hosts-file)")
+	}
+
+	return hosts, nil
+}
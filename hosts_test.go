@@ -0,0 +1,59 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandHost(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "bare host passes through",
+			token: "example.com",
+			want:  []string{"example.com"},
+		},
+		{
+			name:  "bare IP passes through",
+			token: "192.168.1.10",
+			want:  []string{"192.168.1.10"},
+		},
+		{
+			name:  "/30 keeps only the two usable hosts",
+			token: "10.0.0.0/30",
+			want:  []string{"10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name:  "/31 is too small to drop network/broadcast",
+			token: "10.0.0.0/31",
+			want:  []string{"10.0.0.0", "10.0.0.1"},
+		},
+		{
+			name:    "invalid CIDR is an error",
+			token:   "10.0.0.0/99",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := expandHost(tt.token)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expandHost(%q): expected error, got none", tt.token)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandHost(%q): unexpected error: %v", tt.token, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expandHost(%q) = %v, want %v", tt.token, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,82 +1,187 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"net"
-	"sort"
+	"os"
+	"os/signal"
 	"sync"
 	"time"
 )
 
 type PortScanner struct {
-	host       string
-	timeout    time.Duration
-	retryCount int
+	hosts          []string
+	timeout        time.Duration
+	retryCount     int
+	workers        int
+	rateLimiter    *RateLimiter
+	maxOpenPerHost int
+	metrics        *Metrics
+	dialer         Dialer
+	plugins        map[string]bool
+
+	openMu     sync.Mutex
+	openCounts map[string]int
 }
 
-func NewPortScanner(host string, timeout time.Duration, retryCount int) *PortScanner {
+func NewPortScanner(hosts []string, timeout time.Duration, retryCount, workers int, rateLimiter *RateLimiter, maxOpenPerHost int, metrics *Metrics, dialer Dialer, plugins map[string]bool) *PortScanner {
 	return &PortScanner{
-		host:       host,
-		timeout:    timeout,
-		retryCount: retryCount,
+		hosts:          hosts,
+		timeout:        timeout,
+		retryCount:     retryCount,
+		workers:        workers,
+		rateLimiter:    rateLimiter,
+		maxOpenPerHost: maxOpenPerHost,
+		metrics:        metrics,
+		dialer:         dialer,
+		plugins:        plugins,
+		openCounts:     make(map[string]int),
 	}
 }
 
-func (ps *PortScanner) ScanPort(port int, wg *sync.WaitGroup, openPorts chan<- int) {
-	defer wg.Done()
+// Result is one open port discovered on one host, streamed to the caller
+// as soon as it's found rather than buffered until the whole scan finishes.
+type Result struct {
+	Host string `json:"host"`
+	ServiceInfo
+	Findings []Finding `json:"findings,omitempty"`
+}
 
-	address := fmt.Sprintf("%s:%d", ps.host, port)
+// hostSaturated reports whether maxOpenPerHost open ports have already been
+// found on host, so callers can stop probing further ports on it.
+func (ps *PortScanner) hostSaturated(host string) bool {
+	if ps.maxOpenPerHost <= 0 {
+		return false
+	}
+	ps.openMu.Lock()
+	defer ps.openMu.Unlock()
+	return ps.openCounts[host] >= ps.maxOpenPerHost
+}
+
+func (ps *PortScanner) recordOpen(host string) {
+	ps.openMu.Lock()
+	ps.openCounts[host]++
+	ps.openMu.Unlock()
+}
+
+// ScanPort probes a single host:port, waiting on the rate limiter before
+// each dial attempt, and returns its ServiceInfo if the port is open.
+// It gives up early if ctx is cancelled.
+func (ps *PortScanner) ScanPort(ctx context.Context, host string, port int) (ServiceInfo, bool) {
+	address := fmt.Sprintf("%s:%d", host, port)
 
 	// Try multiple times to ensure reliability
 	for attempt := 0; attempt <= ps.retryCount; attempt++ {
-		conn, err := net.DialTimeout("tcp", address, ps.timeout)
+		if err := ps.rateLimiter.Wait(ctx); err != nil {
+			return ServiceInfo{}, false
+		}
+
+		dialStart := time.Now()
+		conn, err := ps.dialer.Dial("tcp", address)
+		ps.metrics.ObserveDial(time.Since(dialStart))
 
 		if err == nil {
 			// Successfully connected, verify it's truly open
 			if conn != nil {
-				// Set a deadline to ensure the connection is real
-				conn.SetDeadline(time.Now().Add(ps.timeout))
-
-				// Try to read/write to verify the connection
-				buf := make([]byte, 1)
-				conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
-				_, _ = conn.Read(buf)
-
+				info := fingerprint(conn, address, port, ps.timeout, ps.dialer)
 				conn.Close()
-				openPorts <- port
-				return
+				ps.metrics.RecordScan()
+				ps.metrics.RecordOpen()
+				return info, true
 			}
 		}
 
 		// If not the last attempt, wait a bit before retrying
 		if attempt < ps.retryCount {
-			time.Sleep(50 * time.Millisecond)
+			select {
+			case <-time.After(50 * time.Millisecond):
+			case <-ctx.Done():
+				return ServiceInfo{}, false
+			}
 		}
 	}
+
+	ps.metrics.RecordScan()
+	return ServiceInfo{}, false
 }
 
-func (ps *PortScanner) Scan(startPort, endPort int) []int {
-	var wg sync.WaitGroup
-	openPorts := make(chan int, endPort-startPort+1)
+type hostPort struct {
+	host string
+	port int
+}
 
-	for port := startPort; port <= endPort; port++ {
-		wg.Add(1)
-		go ps.ScanPort(port, &wg, openPorts)
+// ScanStream scans every host against every port in ports with a bounded
+// pool of workers, streaming each open port back as soon as it's found
+// instead of buffering the whole scan in memory. The returned channel is
+// closed once every host:port pair has been probed or ctx is cancelled.
+func (ps *PortScanner) ScanStream(ctx context.Context, ports []int) <-chan Result {
+	work := make(chan hostPort)
+	go func() {
+		defer close(work)
+		for _, host := range ps.hosts {
+			for _, port := range ports {
+				select {
+				case work <- hostPort{host, port}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	total := len(ps.hosts) * len(ports)
+	workerCount := ps.workers
+	if workerCount <= 0 || workerCount > total {
+		workerCount = total
+	}
+	if workerCount < 1 {
+		workerCount = 1
 	}
 
-	wg.Wait()
-	close(openPorts)
+	results := make(chan Result)
+	var wg sync.WaitGroup
 
-	var results []int
-	for port := range openPorts {
-		results = append(results, port)
+	for i := 0; i < workerCount; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for hp := range work {
+				if ps.hostSaturated(hp.host) {
+					continue
+				}
+				info, open := ps.ScanPort(ctx, hp.host, hp.port)
+				if !open {
+					continue
+				}
+				ps.recordOpen(hp.host)
+				findings := dispatchPlugins(ctx, ps.dialer, ps.rateLimiter, hp.host, hp.port, ps.plugins)
+				select {
+				case results <- Result{Host: hp.host, ServiceInfo: info, Findings: findings}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
 	}
 
-	sort.Ints(results)
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
 	return results
 }
 
+// portRange returns every port from startPort to endPort inclusive.
+func portRange(startPort, endPort int) []int {
+	ports := make([]int, 0, endPort-startPort+1)
+	for port := startPort; port <= endPort; port++ {
+		ports = append(ports, port)
+	}
+	return ports
+}
+
 func getPopularPorts() []int {
 	return []int{
 		// Web Services
@@ -124,35 +229,25 @@ func getPopularPorts() []int {
 	}
 }
 
-func (ps *PortScanner) ScanPopularPorts() []int {
-	ports := getPopularPorts()
-	var wg sync.WaitGroup
-	openPorts := make(chan int, len(ports))
-
-	for _, port := range ports {
-		wg.Add(1)
-		go ps.ScanPort(port, &wg, openPorts)
-	}
-
-	wg.Wait()
-	close(openPorts)
-
-	var results []int
-	for port := range openPorts {
-		results = append(results, port)
-	}
-
-	sort.Ints(results)
-	return results
-}
-
 func main() {
-	host := flag.String("host", "localhost", "Host to scan (e.g., localhost, 192.168.1.1)")
+	host := flag.String("host", "", "Host to scan (e.g., localhost, 192.168.1.1, or a CIDR block); defaults to localhost if neither -host nor -hosts-file is set")
+	hostsFile := flag.String("hosts-file", "", "File with one host/CIDR per line, scanned in addition to -host")
 	startPort := flag.Int("start", 1, "Start port")
 	endPort := flag.Int("end", 1024, "End port")
 	timeout := flag.Int("timeout", 2000, "Connection timeout in milliseconds")
 	retries := flag.Int("retries", 2, "Number of retries for each port")
 	popular := flag.Bool("popular", false, "Scan only popular ports (web, databases, etc.)")
+	workers := flag.Int("workers", 100, "Maximum number of concurrent scan workers")
+	rate := flag.Int("rate", 0, "Maximum dials per rate-window (0 = unlimited)")
+	rateWindow := flag.Duration("rate-window", time.Second, "Window over which -rate dials are allowed")
+	maxOpenPerHost := flag.Int("max-open-per-host", 0, "Stop probing a host after this many open ports are found (0 = unlimited)")
+	outputPath := flag.String("output", "", "Write results to this file instead of stdout")
+	outputFormat := flag.String("format", "text", "Output format: text|json|jsonl|csv")
+	promPort := flag.Int("prom-port", 0, "Serve Prometheus metrics on this port (0 = disabled)")
+	socks5Addr := flag.String("socks5", "", "SOCKS5 proxy address (host:port) to dial through, e.g. for tor/ssh tunnels")
+	socks5User := flag.String("socks5-user", "", "Username for SOCKS5 proxy authentication")
+	socks5Pass := flag.String("socks5-pass", "", "Password for SOCKS5 proxy authentication")
+	plugins := flag.String("plugins", "", "Comma-separated service check plugins to run (default: all registered plugins)")
 	thx := flag.Bool("thx", false, "Show credits")
 
 	flag.Parse()
@@ -172,33 +267,92 @@ func main() {
 		return
 	}
 
-	scanner := NewPortScanner(*host, time.Duration(*timeout)*time.Millisecond, *retries)
-	startTime := time.Now()
+	effectiveHost := *host
+	if effectiveHost == "" && *hostsFile == "" {
+		effectiveHost = "localhost"
+	}
 
-	var openPorts []int
+	hosts, err := resolveHosts(effectiveHost, *hostsFile)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	writer, err := newOutputWriter(*outputFormat, *outputPath)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	defer func() {
+		if err := writer.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "closing output: %v\n", err)
+		}
+	}()
+
+	// Progress/status messages go to stdout only when they won't corrupt
+	// structured output written there (text format, or output going to a
+	// separate file).
+	statusOut := os.Stdout
+	if *outputFormat != "text" && *outputPath == "" {
+		statusOut = os.Stderr
+	}
+
+	var rateLimiter *RateLimiter
+	if *rate > 0 {
+		rateLimiter = NewRateLimiter(*rate, *rateWindow)
+		defer rateLimiter.Close()
+	}
 
+	var metrics *Metrics
+	if *promPort > 0 {
+		metrics = NewMetrics()
+		go func() {
+			if err := serveMetrics(fmt.Sprintf(":%d", *promPort), metrics); err != nil {
+				fmt.Fprintf(os.Stderr, "metrics server: %v\n", err)
+			}
+		}()
+	}
+
+	dialer, err := NewDialer(time.Duration(*timeout)*time.Millisecond, *socks5Addr, *socks5User, *socks5Pass)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+
+	scanner := NewPortScanner(hosts, time.Duration(*timeout)*time.Millisecond, *retries, *workers, rateLimiter, *maxOpenPerHost, metrics, dialer, parsePluginList(*plugins))
+
+	var ports []int
 	if *popular {
-		fmt.Printf("Scanning %s for popular ports (web, databases, remote access, etc.)...\n", *host)
-		openPorts = scanner.ScanPopularPorts()
+		fmt.Fprintf(statusOut, "Scanning %d host(s) for popular ports (web, databases, remote access, etc.)...\n", len(hosts))
+		ports = getPopularPorts()
 	} else {
-		fmt.Printf("Scanning %s from port %d to %d...\n", *host, *startPort, *endPort)
-		fmt.Println("This may take a while depending on the range...")
-		openPorts = scanner.Scan(*startPort, *endPort)
+		fmt.Fprintf(statusOut, "Scanning %d host(s) from port %d to %d...\n", len(hosts), *startPort, *endPort)
+		fmt.Fprintln(statusOut, "This may take a while depending on the range...")
+		ports = portRange(*startPort, *endPort)
 	}
 
-	elapsed := time.Since(startTime)
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
 
-	fmt.Printf("\nScan completed in %s\n", elapsed)
-	fmt.Printf("Found %d open port(s):\n", len(openPorts))
+	startTime := time.Now()
+	found := 0
 
-	if len(openPorts) > 0 {
-		for _, port := range openPorts {
-			serviceName := getServiceName(port)
-			fmt.Printf("  Port %d is open %s\n", port, serviceName)
+	for result := range scanner.ScanStream(ctx, ports) {
+		found++
+		if err := writer.WriteResult(result); err != nil {
+			fmt.Fprintf(os.Stderr, "writing result: %v\n", err)
 		}
-	} else {
-		fmt.Println("  No open ports found in the specified range")
 	}
+
+	elapsed := time.Since(startTime)
+
+	if ctx.Err() != nil {
+		fmt.Fprintf(statusOut, "\nScan interrupted after %s, found %d open port(s)\n", elapsed, found)
+		return
+	}
+
+	fmt.Fprintf(statusOut, "\nScan completed in %s\n", elapsed)
+	fmt.Fprintf(statusOut, "Found %d open port(s)\n", found)
 }
 
 func getServiceName(port int) string {
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBucketBounds are the dial-latency histogram bucket upper bounds,
+// in seconds, exposed as Prometheus "le" buckets.
+var latencyBucketBounds = []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2, 5}
+
+// Metrics tracks the counters and dial-latency histogram exposed on
+// /metrics so a long-running scan can be observed live.
+type Metrics struct {
+	portsScanned uint64
+	portsOpen    uint64
+
+	mu             sync.Mutex
+	latencyBuckets map[float64]uint64
+	latencyCount   uint64
+	latencySum     float64
+}
+
+// NewMetrics creates an empty Metrics with all histogram buckets zeroed.
+func NewMetrics() *Metrics {
+	buckets := make(map[float64]uint64, len(latencyBucketBounds))
+	for _, bound := range latencyBucketBounds {
+		buckets[bound] = 0
+	}
+	return &Metrics{latencyBuckets: buckets}
+}
+
+// RecordScan increments the total number of ports scanned.
+func (m *Metrics) RecordScan() {
+	if m == nil {
+		return
+	}
+	atomic.AddUint64(&m.portsScanned, 1)
+}
+
+// RecordOpen increments the total number of ports found open.
+func (m *Metrics) RecordOpen() {
+	if m == nil {
+		return
+	}
+	atomic.AddUint64(&m.portsOpen, 1)
+}
+
+// ObserveDial records how long a single dial attempt took, whether direct
+// or through the SOCKS5 proxy.
+func (m *Metrics) ObserveDial(d time.Duration) {
+	if m == nil {
+		return
+	}
+	seconds := d.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latencyCount++
+	m.latencySum += seconds
+	for _, bound := range latencyBucketBounds {
+		if seconds <= bound {
+			m.latencyBuckets[bound]++
+		}
+	}
+}
+
+// WriteTo renders the current metrics in the Prometheus text exposition
+// format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+
+	write := func(format string, args ...interface{}) error {
+		n, err := fmt.Fprintf(w, format, args...)
+		written += int64(n)
+		return err
+	}
+
+	if err := write("# HELP goscanports_ports_scanned_total Total number of ports probed.\n"); err != nil {
+		return written, err
+	}
+	if err := write("# TYPE goscanports_ports_scanned_total counter\n"); err != nil {
+		return written, err
+	}
+	if err := write("goscanports_ports_scanned_total %d\n", atomic.LoadUint64(&m.portsScanned)); err != nil {
+		return written, err
+	}
+
+	if err := write("# HELP goscanports_ports_open_total Total number of ports found open.\n"); err != nil {
+		return written, err
+	}
+	if err := write("# TYPE goscanports_ports_open_total counter\n"); err != nil {
+		return written, err
+	}
+	if err := write("goscanports_ports_open_total %d\n", atomic.LoadUint64(&m.portsOpen)); err != nil {
+		return written, err
+	}
+
+	m.mu.Lock()
+	bounds := make([]float64, len(latencyBucketBounds))
+	copy(bounds, latencyBucketBounds)
+	sort.Float64s(bounds)
+	counts := make(map[float64]uint64, len(m.latencyBuckets))
+	for k, v := range m.latencyBuckets {
+		counts[k] = v
+	}
+	count, sum := m.latencyCount, m.latencySum
+	m.mu.Unlock()
+
+	if err := write("# HELP goscanports_dial_latency_seconds Histogram of dial attempt latency.\n"); err != nil {
+		return written, err
+	}
+	if err := write("# TYPE goscanports_dial_latency_seconds histogram\n"); err != nil {
+		return written, err
+	}
+	for _, bound := range bounds {
+		if err := write("goscanports_dial_latency_seconds_bucket{le=\"%g\"} %d\n", bound, counts[bound]); err != nil {
+			return written, err
+		}
+	}
+	if err := write("goscanports_dial_latency_seconds_bucket{le=\"+Inf\"} %d\n", count); err != nil {
+		return written, err
+	}
+	if err := write("goscanports_dial_latency_seconds_sum %g\n", sum); err != nil {
+		return written, err
+	}
+	if err := write("goscanports_dial_latency_seconds_count %d\n", count); err != nil {
+		return written, err
+	}
+
+	return written, nil
+}
+
+// serveMetrics starts an HTTP server exposing /metrics on addr. It runs
+// until the process exits, so callers invoke it in its own goroutine.
+func serveMetrics(addr string, m *Metrics) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		m.WriteTo(w)
+	})
+	return http.ListenAndServe(addr, mux)
+}
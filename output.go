@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// OutputWriter consumes scan Results as they're discovered and renders them
+// in some format. WriteResult is called once per open port; Close flushes
+// any buffered state (the aggregate JSON writer needs all results before it
+// can emit its single document) and releases the underlying file, if any.
+type OutputWriter interface {
+	WriteResult(Result) error
+	Close() error
+}
+
+// newOutputWriter builds the OutputWriter for format, writing to path if
+// given or to stdout otherwise.
+func newOutputWriter(format, path string) (OutputWriter, error) {
+	w, closeFile, err := openOutput(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case "", "text":
+		return &textWriter{w: w, closeFile: closeFile}, nil
+	case "jsonl":
+		return &jsonlWriter{enc: json.NewEncoder(w), closeFile: closeFile}, nil
+	case "csv":
+		return &csvWriter{w: csv.NewWriter(w), closeFile: closeFile}, nil
+	case "json":
+		return &jsonWriter{w: w, closeFile: closeFile}, nil
+	default:
+		if closeFile != nil {
+			closeFile()
+		}
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// openOutput returns the writer to use (path's file, or stdout) and a
+// closer for it, if any.
+func openOutput(path string) (io.Writer, func() error, error) {
+	if path == "" {
+		return os.Stdout, nil, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening -output file: %w", err)
+	}
+	return f, f.Close, nil
+}
+
+// textWriter prints one human-readable line per open port, the scanner's
+// traditional output.
+type textWriter struct {
+	w         io.Writer
+	closeFile func() error
+}
+
+func (tw *textWriter) WriteResult(r Result) error {
+	if _, err := fmt.Fprintf(tw.w, "  %s: %s\n", r.Host, r.ServiceInfo); err != nil {
+		return err
+	}
+	for _, finding := range r.Findings {
+		if _, err := fmt.Fprintf(tw.w, "    [%s] %s\n", finding.Plugin, finding.Detail); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tw *textWriter) Close() error {
+	if tw.closeFile == nil {
+		return nil
+	}
+	return tw.closeFile()
+}
+
+// jsonlRecord is the shape written to jsonl/json output: a Result plus the
+// time it was discovered.
+type jsonlRecord struct {
+	Host      string    `json:"host"`
+	Port      int       `json:"port"`
+	Service   string    `json:"service,omitempty"`
+	Banner    string    `json:"banner,omitempty"`
+	TLS       *TLSInfo  `json:"tls,omitempty"`
+	HTTP      *HTTPInfo `json:"http,omitempty"`
+	Findings  []Finding `json:"findings,omitempty"`
+	ScannedAt time.Time `json:"scanned_at"`
+}
+
+func toRecord(r Result) jsonlRecord {
+	return jsonlRecord{
+		Host:      r.Host,
+		Port:      r.Port,
+		Service:   r.Service,
+		Banner:    r.Banner,
+		TLS:       r.TLS,
+		HTTP:      r.HTTP,
+		Findings:  r.Findings,
+		ScannedAt: time.Now(),
+	}
+}
+
+// jsonlWriter emits one JSON object per open port, as soon as it's found.
+type jsonlWriter struct {
+	enc       *json.Encoder
+	closeFile func() error
+}
+
+func (jw *jsonlWriter) WriteResult(r Result) error {
+	return jw.enc.Encode(toRecord(r))
+}
+
+func (jw *jsonlWriter) Close() error {
+	if jw.closeFile == nil {
+		return nil
+	}
+	return jw.closeFile()
+}
+
+// csvWriter emits one CSV row per open port, writing the header on the
+// first result.
+type csvWriter struct {
+	w         *csv.Writer
+	closeFile func() error
+	wroteHead bool
+}
+
+var csvHeader = []string{"ip", "port", "service", "title", "status", "server", "tls_cn"}
+
+func (cw *csvWriter) WriteResult(r Result) error {
+	if !cw.wroteHead {
+		if err := cw.w.Write(csvHeader); err != nil {
+			return err
+		}
+		cw.wroteHead = true
+	}
+
+	var title, status, server, tlsCN string
+	if r.HTTP != nil {
+		title = r.HTTP.Title
+		server = r.HTTP.Server
+		if r.HTTP.StatusCode != 0 {
+			status = strconv.Itoa(r.HTTP.StatusCode)
+		}
+	}
+	if r.TLS != nil {
+		tlsCN = r.TLS.CommonName
+	}
+
+	row := []string{r.Host, strconv.Itoa(r.Port), r.Service, title, status, server, tlsCN}
+	if err := cw.w.Write(row); err != nil {
+		return err
+	}
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	if err := cw.w.Error(); err != nil {
+		return err
+	}
+	if cw.closeFile == nil {
+		return nil
+	}
+	return cw.closeFile()
+}
+
+// jsonWriter buffers every result and emits a single aggregated JSON
+// document on Close, unlike jsonlWriter/csvWriter which stream.
+type jsonWriter struct {
+	w         io.Writer
+	closeFile func() error
+	records   []jsonlRecord
+}
+
+func (jsw *jsonWriter) WriteResult(r Result) error {
+	jsw.records = append(jsw.records, toRecord(r))
+	return nil
+}
+
+func (jsw *jsonWriter) Close() error {
+	enc := json.NewEncoder(jsw.w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(jsw.records); err != nil {
+		return err
+	}
+	if jsw.closeFile == nil {
+		return nil
+	}
+	return jsw.closeFile()
+}
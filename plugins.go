@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Finding is a plugin's report about something it discovered while probing
+// an open port — e.g. an unauthenticated database, or a legacy protocol
+// dialect. Findings are written with the open port they belong to in text,
+// json and jsonl output; csv output has no column for them and drops them.
+type Finding struct {
+	Plugin string `json:"plugin"`
+	Host   string `json:"host"`
+	Port   int    `json:"port"`
+	Detail string `json:"detail"`
+}
+
+// Plugin is a lightweight service check dispatched against a port once
+// ScanPort has confirmed it's open. Run gets its own fresh connection (the
+// one used to confirm the port was open has already been closed) and
+// returns an error when there's nothing to report, not just when the probe
+// failed outright.
+type Plugin interface {
+	Name() string
+	Ports() []int
+	Run(ctx context.Context, conn net.Conn, host string, port int) (Finding, error)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []Plugin
+)
+
+// Register adds a plugin to the built-in registry. Called from init() by
+// each plugin implementation.
+func Register(p Plugin) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry = append(registry, p)
+}
+
+// pluginsForPort returns the registered plugins that claim port, filtered
+// to enabled when it's non-nil (a nil enabled set means "run everything").
+func pluginsForPort(port int, enabled map[string]bool) []Plugin {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	var matched []Plugin
+	for _, p := range registry {
+		if enabled != nil && !enabled[p.Name()] {
+			continue
+		}
+		for _, claimed := range p.Ports() {
+			if claimed == port {
+				matched = append(matched, p)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// parsePluginList turns a comma-separated -plugins flag value into an
+// enabled-set, or nil if csv is empty (meaning "run every plugin").
+func parsePluginList(csv string) map[string]bool {
+	if csv == "" {
+		return nil
+	}
+	enabled := make(map[string]bool)
+	for _, name := range strings.Split(csv, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			enabled[name] = true
+		}
+	}
+	return enabled
+}
+
+// dispatchPlugins opens a fresh connection per matching plugin and runs it,
+// collecting whatever findings come back. Each dial waits on rateLimiter
+// first, the same as ScanPort, so enabling plugins doesn't add connections
+// exempt from the configured rate. Dial or probe failures are skipped
+// rather than treated as fatal — a plugin not applying isn't an error
+// condition for the scan as a whole.
+func dispatchPlugins(ctx context.Context, dialer Dialer, rateLimiter *RateLimiter, host string, port int, enabled map[string]bool) []Finding {
+	var findings []Finding
+
+	for _, p := range pluginsForPort(port, enabled) {
+		if err := rateLimiter.Wait(ctx); err != nil {
+			return findings
+		}
+
+		conn, err := dialer.Dial("tcp", fmt.Sprintf("%s:%d", host, port))
+		if err != nil {
+			continue
+		}
+		conn.SetDeadline(time.Now().Add(3 * time.Second))
+
+		finding, err := p.Run(ctx, conn, host, port)
+		conn.Close()
+		if err != nil {
+			continue
+		}
+
+		finding.Plugin = p.Name()
+		finding.Host = host
+		finding.Port = port
+		findings = append(findings, finding)
+	}
+
+	return findings
+}
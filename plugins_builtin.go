@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+)
+
+func init() {
+	Register(redisUnauthPlugin{})
+	Register(mongoUnauthPlugin{})
+	Register(elasticsearchUnauthPlugin{})
+	Register(mysqlBannerPlugin{})
+	Register(smbDialectPlugin{})
+}
+
+// redisUnauthPlugin flags a Redis instance that answers INFO without
+// requiring AUTH first.
+type redisUnauthPlugin struct{}
+
+func (redisUnauthPlugin) Name() string { return "redis-unauth" }
+func (redisUnauthPlugin) Ports() []int { return []int{6379} }
+func (redisUnauthPlugin) Run(ctx context.Context, conn net.Conn, host string, port int) (Finding, error) {
+	if _, err := conn.Write([]byte("INFO\r\n")); err != nil {
+		return Finding{}, err
+	}
+
+	buf := make([]byte, bannerReadLimit)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return Finding{}, err
+	}
+
+	resp := string(buf[:n])
+	if !strings.Contains(resp, "redis_version") {
+		return Finding{}, fmt.Errorf("not an unauthenticated redis")
+	}
+
+	return Finding{Detail: "accepts unauthenticated INFO command"}, nil
+}
+
+// mongoUnauthPlugin flags a MongoDB instance that answers an isMaster
+// command without requiring authentication.
+type mongoUnauthPlugin struct{}
+
+func (mongoUnauthPlugin) Name() string { return "mongo-unauth" }
+func (mongoUnauthPlugin) Ports() []int { return []int{27017} }
+func (mongoUnauthPlugin) Run(ctx context.Context, conn net.Conn, host string, port int) (Finding, error) {
+	if _, err := conn.Write(isMasterQuery()); err != nil {
+		return Finding{}, err
+	}
+
+	buf := make([]byte, bannerReadLimit)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return Finding{}, err
+	}
+
+	resp := string(buf[:n])
+	if !strings.Contains(resp, "ismaster") {
+		return Finding{}, fmt.Errorf("not an unauthenticated mongod")
+	}
+
+	return Finding{Detail: "accepts unauthenticated isMaster command"}, nil
+}
+
+// isMasterQuery builds a legacy OP_QUERY wire-protocol message asking
+// admin.$cmd for {isMaster: 1}.
+func isMasterQuery() []byte {
+	// BSON document: {"isMaster": 1}
+	doc := []byte{}
+	doc = append(doc, 0x10)                      // int32 type
+	doc = append(doc, []byte("isMaster\x00")...) // field name
+	doc = append(doc, 1, 0, 0, 0)                // int32 value = 1
+	doc = append(doc, 0x00)                      // document terminator
+	docLen := make([]byte, 4)
+	binary.LittleEndian.PutUint32(docLen, uint32(len(doc)+4))
+	doc = append(docLen, doc...)
+
+	body := []byte{}
+	body = append(body, 0, 0, 0, 0)                  // flags
+	body = append(body, []byte("admin.$cmd\x00")...) // fullCollectionName
+	body = append(body, 0, 0, 0, 0)                  // numberToSkip
+	body = append(body, 0xff, 0xff, 0xff, 0xff)      // numberToReturn = -1
+	body = append(body, doc...)                      // query document
+
+	header := make([]byte, 16)
+	binary.LittleEndian.PutUint32(header[0:4], uint32(16+len(body))) // messageLength
+	binary.LittleEndian.PutUint32(header[4:8], 1)                    // requestID
+	binary.LittleEndian.PutUint32(header[8:12], 0)                   // responseTo
+	binary.LittleEndian.PutUint32(header[12:16], 2004)               // opCode OP_QUERY
+
+	return append(header, body...)
+}
+
+// elasticsearchUnauthPlugin flags an Elasticsearch cluster that answers the
+// root endpoint without requiring authentication.
+type elasticsearchUnauthPlugin struct{}
+
+func (elasticsearchUnauthPlugin) Name() string { return "elasticsearch-unauth" }
+func (elasticsearchUnauthPlugin) Ports() []int { return []int{9200} }
+func (elasticsearchUnauthPlugin) Run(ctx context.Context, conn net.Conn, host string, port int) (Finding, error) {
+	if _, err := conn.Write([]byte("GET / HTTP/1.0\r\n\r\n")); err != nil {
+		return Finding{}, err
+	}
+
+	buf := make([]byte, bannerReadLimit)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return Finding{}, err
+	}
+
+	resp := string(buf[:n])
+	if !strings.HasPrefix(resp, "HTTP/") || !strings.Contains(resp, "cluster_name") {
+		return Finding{}, fmt.Errorf("not an unauthenticated elasticsearch")
+	}
+
+	return Finding{Detail: "accepts unauthenticated root endpoint request"}, nil
+}
+
+// mysqlBannerPlugin reads the MySQL server handshake and reports the
+// server version string it advertises.
+type mysqlBannerPlugin struct{}
+
+func (mysqlBannerPlugin) Name() string { return "mysql-banner" }
+func (mysqlBannerPlugin) Ports() []int { return []int{3306} }
+func (mysqlBannerPlugin) Run(ctx context.Context, conn net.Conn, host string, port int) (Finding, error) {
+	buf := make([]byte, bannerReadLimit)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return Finding{}, err
+	}
+
+	// Handshake packet: 3-byte length, 1-byte sequence, 1-byte protocol
+	// version, then a NUL-terminated server version string.
+	if n < 6 {
+		return Finding{}, fmt.Errorf("handshake too short")
+	}
+
+	versionEnd := 5
+	for versionEnd < n && buf[versionEnd] != 0x00 {
+		versionEnd++
+	}
+	if versionEnd >= n {
+		return Finding{}, fmt.Errorf("no version string in handshake")
+	}
+
+	return Finding{Detail: fmt.Sprintf("server version %s", buf[5:versionEnd])}, nil
+}
+
+// smbDialectPlugin sends an SMB1 negotiate-protocol request offering only
+// the NT LM 0.12 dialect, and reports a finding when the server accepts it.
+// A server that still accepts SMB1 is the precondition exploited by
+// MS17-010/EternalBlue. Newer SMB2/SMB3 dialects are not probed.
+type smbDialectPlugin struct{}
+
+func (smbDialectPlugin) Name() string { return "smb-dialect" }
+func (smbDialectPlugin) Ports() []int { return []int{445} }
+func (smbDialectPlugin) Run(ctx context.Context, conn net.Conn, host string, port int) (Finding, error) {
+	if _, err := conn.Write(smbNegotiateRequest()); err != nil {
+		return Finding{}, err
+	}
+
+	buf := make([]byte, bannerReadLimit)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return Finding{}, err
+	}
+
+	if n < 8 || string(buf[4:8]) != "\xffSMB" {
+		return Finding{}, fmt.Errorf("not an SMB negotiate response")
+	}
+
+	// The negotiate response body starts right after the 4-byte NBSS
+	// header and the 32-byte SMB header: a 1-byte WordCount followed by
+	// WordCount words, the first of which is the DialectIndex. A patched,
+	// SMB1-disabled server still answers with a valid SMB1 header but
+	// sets DialectIndex to 0xFFFF to mean "none of the offered dialects
+	// accepted" — only a real index selects our single NT LM 0.12 offer.
+	const bodyOffset = 4 + 32
+	if n < bodyOffset+3 {
+		return Finding{}, fmt.Errorf("negotiate response too short")
+	}
+
+	wordCount := buf[bodyOffset]
+	if wordCount < 1 {
+		return Finding{}, fmt.Errorf("server rejected SMB1 negotiation")
+	}
+
+	dialectIndex := binary.LittleEndian.Uint16(buf[bodyOffset+1 : bodyOffset+3])
+	if dialectIndex == 0xFFFF {
+		return Finding{}, fmt.Errorf("server rejected SMB1 dialect")
+	}
+
+	return Finding{Detail: "negotiated SMB1 dialect (legacy, check MS17-010/SMBGhost exposure)"}, nil
+}
+
+// smbNegotiateRequest builds a minimal SMB1 "Negotiate Protocol Request"
+// offering only the classic NT LM 0.12 dialect, which is enough to confirm
+// whether a listener still speaks SMB1 at all.
+func smbNegotiateRequest() []byte {
+	dialect := []byte{0x02}
+	dialect = append(dialect, []byte("NT LM 0.12\x00")...)
+
+	header := []byte{
+		0xff, 'S', 'M', 'B', // protocol
+		0x72,                   // command: negotiate
+		0x00, 0x00, 0x00, 0x00, // status
+		0x18,       // flags
+		0x01, 0x28, // flags2
+		0x00, 0x00, // process ID high
+		0, 0, 0, 0, 0, 0, 0, 0, // signature
+		0x00, 0x00, // reserved
+		0x00, 0x00, // tree ID
+		0x00, 0x00, // process ID
+		0x00, 0x00, // user ID
+		0x00, 0x00, // multiplex ID
+	}
+
+	params := []byte{0x00} // word count
+	byteCount := make([]byte, 2)
+	binary.LittleEndian.PutUint16(byteCount, uint16(len(dialect)))
+
+	body := append(header, params...)
+	body = append(body, byteCount...)
+	body = append(body, dialect...)
+
+	nbssHeader := make([]byte, 4)
+	nbssHeader[0] = 0x00
+	length := len(body)
+	nbssHeader[1] = byte(length >> 16)
+	nbssHeader[2] = byte(length >> 8)
+	nbssHeader[3] = byte(length)
+
+	return append(nbssHeader, body...)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+)
+
+// smbNegotiateResponse builds a fake SMB1 negotiate response body with the
+// given WordCount and DialectIndex, padded out to a valid minimum length.
+func smbNegotiateResponse(wordCount byte, dialectIndex uint16) []byte {
+	resp := make([]byte, 4+32) // NBSS header + SMB header
+	resp[4], resp[5], resp[6], resp[7] = 0xff, 'S', 'M', 'B'
+
+	body := make([]byte, 3)
+	body[0] = wordCount
+	binary.LittleEndian.PutUint16(body[1:3], dialectIndex)
+
+	return append(resp, body...)
+}
+
+func TestSmbDialectPluginRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		response   []byte
+		wantErr    bool
+		wantDetail bool
+	}{
+		{
+			name:       "dialect selected is a finding",
+			response:   smbNegotiateResponse(1, 0x0000),
+			wantDetail: true,
+		},
+		{
+			name:     "0xFFFF means the server rejected SMB1",
+			response: smbNegotiateResponse(1, 0xFFFF),
+			wantErr:  true,
+		},
+		{
+			name:     "zero WordCount means the server rejected negotiation",
+			response: smbNegotiateResponse(0, 0x0000),
+			wantErr:  true,
+		},
+		{
+			name:     "not an SMB response at all",
+			response: []byte("HTTP/1.1 200 OK\r\n\r\n"),
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+			defer server.Close()
+
+			go func() {
+				buf := make([]byte, bannerReadLimit)
+				server.Read(buf) // drain the negotiate request
+				server.Write(tt.response)
+			}()
+
+			finding, err := smbDialectPlugin{}.Run(context.Background(), client, "host", 445)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Run(): expected error, got finding %+v", finding)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run(): unexpected error: %v", err)
+			}
+			if tt.wantDetail && finding.Detail == "" {
+				t.Errorf("Run(): expected a non-empty Detail, got %+v", finding)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimiter is a channel-based token bucket: callers block on Wait until a
+// token is available, and a background ticker refills up to count tokens
+// every window, capping bursts at count per window.
+type RateLimiter struct {
+	tokens chan struct{}
+	stop   chan struct{}
+}
+
+// NewRateLimiter creates a RateLimiter that allows up to count operations
+// per window. The bucket starts full so the first burst isn't throttled
+// waiting on the initial tick.
+func NewRateLimiter(count int, window time.Duration) *RateLimiter {
+	rl := &RateLimiter{
+		tokens: make(chan struct{}, count),
+		stop:   make(chan struct{}),
+	}
+
+	for i := 0; i < count; i++ {
+		rl.tokens <- struct{}{}
+	}
+
+	go rl.refill(count, window)
+	return rl
+}
+
+func (rl *RateLimiter) refill(count int, window time.Duration) {
+	ticker := time.NewTicker(window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for i := 0; i < count; i++ {
+				select {
+				case rl.tokens <- struct{}{}:
+				default:
+					// Bucket already full; drop the extra token.
+				}
+			}
+		case <-rl.stop:
+			return
+		}
+	}
+}
+
+// Wait blocks until a token is available or ctx is cancelled. A nil
+// *RateLimiter is treated as "no limit" so callers don't need to
+// special-case an unconfigured limiter.
+func (rl *RateLimiter) Wait(ctx context.Context) error {
+	if rl == nil {
+		return nil
+	}
+	select {
+	case <-rl.tokens:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops the refill goroutine. Safe to call on a nil *RateLimiter.
+func (rl *RateLimiter) Close() {
+	if rl == nil {
+		return
+	}
+	close(rl.stop)
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLSInfo captures the identifying details of a peer certificate observed
+// during a TLS handshake against an open port.
+type TLSInfo struct {
+	CommonName string   `json:"cn,omitempty"`
+	SANs       []string `json:"sans,omitempty"`
+	ALPN       string   `json:"alpn,omitempty"`
+}
+
+// HTTPInfo captures the parts of an HTTP response useful for fingerprinting
+// a web service without doing a full client request.
+type HTTPInfo struct {
+	StatusCode int    `json:"status,omitempty"`
+	Server     string `json:"server,omitempty"`
+	Title      string `json:"title,omitempty"`
+}
+
+// ServiceInfo is the per-port result of the service-identification layer:
+// the open port itself plus whatever banner, TLS, or HTTP metadata could be
+// gathered about the service listening on it.
+type ServiceInfo struct {
+	Port    int       `json:"port"`
+	Service string    `json:"service,omitempty"`
+	Banner  string    `json:"banner,omitempty"`
+	TLS     *TLSInfo  `json:"tls,omitempty"`
+	HTTP    *HTTPInfo `json:"http,omitempty"`
+}
+
+// bannerSignature maps a regex over a greeting/response banner to a
+// human-readable service name, for protocols that identify themselves in
+// their first line (e.g. "220 ... FTP", "SSH-2.0-OpenSSH").
+type bannerSignature struct {
+	pattern *regexp.Regexp
+	name    string
+}
+
+var bannerSignatures = []bannerSignature{
+	{regexp.MustCompile(`(?i)^SSH-`), "(SSH)"},
+	{regexp.MustCompile(`(?i)^220[ -].*FTP`), "(FTP)"},
+	{regexp.MustCompile(`(?i)^220[ -].*SMTP`), "(SMTP)"},
+	{regexp.MustCompile(`(?i)^\+OK.*POP3`), "(POP3)"},
+	{regexp.MustCompile(`(?i)^\* OK.*IMAP`), "(IMAP)"},
+	{regexp.MustCompile(`(?i)^-ERR|^\+PONG|redis_version`), "(Redis)"},
+	{regexp.MustCompile(`(?i)^HTTP/\d\.\d`), "(HTTP)"},
+	{regexp.MustCompile(`(?i)mysql_native_password|mariadb`), "(MySQL)"},
+}
+
+// tlsPorts lists the well-known ports where a plaintext probe is pointless
+// because the service always speaks TLS from the first byte.
+var tlsPorts = map[int]bool{
+	443:  true,
+	8443: true,
+	993:  true,
+	995:  true,
+	465:  true,
+}
+
+// protocolProbes gives a small nudge to protocols that otherwise wait for
+// the client to speak first, keyed by the port they're conventionally found
+// on. Protocols that send a greeting on connect (FTP, SSH, SMTP, POP3,
+// IMAP) need no probe and are handled by the passive banner read.
+var protocolProbes = map[int][]byte{
+	80:   []byte("HEAD / HTTP/1.0\r\n\r\n"),
+	8080: []byte("HEAD / HTTP/1.0\r\n\r\n"),
+	8000: []byte("HEAD / HTTP/1.0\r\n\r\n"),
+	3000: []byte("HEAD / HTTP/1.0\r\n\r\n"),
+	4200: []byte("HEAD / HTTP/1.0\r\n\r\n"),
+	5000: []byte("HEAD / HTTP/1.0\r\n\r\n"),
+	6379: []byte("PING\r\n"),
+	9200: []byte("GET / HTTP/1.0\r\n\r\n"),
+	5984: []byte("GET / HTTP/1.0\r\n\r\n"),
+	9090: []byte("GET / HTTP/1.0\r\n\r\n"),
+}
+
+const bannerReadLimit = 4096
+
+// identifyService turns a captured banner into a human-readable service
+// name, falling back to the static port map when the banner doesn't match
+// any known signature (or is empty).
+func identifyService(port int, banner string) string {
+	for _, sig := range bannerSignatures {
+		if sig.pattern.MatchString(banner) {
+			return sig.name
+		}
+	}
+	return getServiceName(port)
+}
+
+// fingerprint gathers service-identification metadata for an already-open
+// TCP connection: it passively waits for a greeting banner, falls back to a
+// small protocol probe if nothing arrives, upgrades to TLS when the port or
+// the banner itself indicates one, and parses HTTP responses for title,
+// status, and server header.
+func fingerprint(conn net.Conn, address string, port int, timeout time.Duration, dialer Dialer) ServiceInfo {
+	info := ServiceInfo{Port: port}
+
+	conn.SetDeadline(time.Now().Add(timeout))
+	banner := readBanner(conn)
+
+	if banner == "" {
+		if probe, ok := protocolProbes[port]; ok {
+			conn.SetWriteDeadline(time.Now().Add(timeout))
+			if _, err := conn.Write(probe); err == nil {
+				conn.SetReadDeadline(time.Now().Add(timeout))
+				banner = readBanner(conn)
+			}
+		}
+	}
+
+	if tlsPorts[port] || looksLikeTLSRecord(banner) {
+		if tlsInfo := probeTLS(dialer, address, timeout); tlsInfo != nil {
+			info.TLS = tlsInfo
+		}
+	}
+
+	if httpInfo := parseHTTPBanner(banner); httpInfo != nil {
+		info.HTTP = httpInfo
+	}
+
+	info.Banner = banner
+	info.Service = identifyService(port, banner)
+	return info
+}
+
+// readBanner reads up to bannerReadLimit bytes from conn, relying on the
+// deadline already set by the caller to bound how long it waits for a
+// server that never speaks first.
+func readBanner(conn net.Conn) string {
+	buf := make([]byte, bannerReadLimit)
+	n, _ := conn.Read(buf)
+	if n <= 0 {
+		return ""
+	}
+	return string(buf[:n])
+}
+
+// looksLikeTLSRecord reports whether banner begins with a TLS record header
+// (content type 0x16 handshake, version 0x03 major), which happens when a
+// plaintext probe on a non-standard port actually hit a TLS listener.
+func looksLikeTLSRecord(banner string) bool {
+	return len(banner) >= 2 && banner[0] == 0x16 && banner[1] == 0x03
+}
+
+// probeTLS performs a fresh TLS handshake against address, dialing through
+// dialer so it honors any configured SOCKS5 proxy, and extracts the peer
+// certificate's CommonName/SANs and the negotiated ALPN protocol.
+// Verification is skipped deliberately: the scanner is fingerprinting
+// whatever certificate a host presents, not validating trust.
+func probeTLS(dialer Dialer, address string, timeout time.Duration) *TLSInfo {
+	conn, err := dialer.Dial("tcp", address)
+	if err != nil {
+		return nil
+	}
+
+	tlsConn := tls.Client(conn, &tls.Config{InsecureSkipVerify: true})
+	tlsConn.SetDeadline(time.Now().Add(timeout))
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil
+	}
+	defer tlsConn.Close()
+
+	state := tlsConn.ConnectionState()
+	if len(state.PeerCertificates) == 0 {
+		return nil
+	}
+
+	cert := state.PeerCertificates[0]
+	return &TLSInfo{
+		CommonName: cert.Subject.CommonName,
+		SANs:       cert.DNSNames,
+		ALPN:       state.NegotiatedProtocol,
+	}
+}
+
+var titleRegexp = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// parseHTTPBanner parses an HTTP status line, Server header, and <title>
+// out of a raw response banner. It returns nil if banner doesn't look like
+// an HTTP response at all.
+func parseHTTPBanner(banner string) *HTTPInfo {
+	if !strings.HasPrefix(banner, "HTTP/") {
+		return nil
+	}
+
+	info := &HTTPInfo{}
+	scanner := bufio.NewScanner(strings.NewReader(banner))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(line, "HTTP/") {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 {
+				if code, err := strconv.Atoi(fields[1]); err == nil {
+					info.StatusCode = code
+				}
+			}
+			continue
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(strings.TrimSpace(name), "Server") {
+			info.Server = strings.TrimSpace(value)
+		}
+	}
+
+	if m := titleRegexp.FindStringSubmatch(banner); len(m) == 2 {
+		info.Title = strings.TrimSpace(m[1])
+	}
+
+	return info
+}
+
+// String renders a ServiceInfo the way the CLI prints a discovered port.
+func (s ServiceInfo) String() string {
+	parts := []string{fmt.Sprintf("Port %d is open %s", s.Port, s.Service)}
+	if s.TLS != nil && s.TLS.CommonName != "" {
+		parts = append(parts, fmt.Sprintf("[TLS CN=%s]", s.TLS.CommonName))
+	}
+	if s.HTTP != nil && s.HTTP.Title != "" {
+		parts = append(parts, fmt.Sprintf("[%q]", s.HTTP.Title))
+	}
+	return strings.Join(parts, " ")
+}